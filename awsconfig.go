@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// defaultRegion is used when no region is configured anywhere in the
+// credential chain; Route53 is a global service, but the SDK still needs
+// a region to sign requests with.
+const defaultRegion = "us-east-1"
+
+// awsCredentials holds the flag-driven overrides for how the Route53
+// client authenticates. The zero value falls back entirely to the SDK's
+// default credential chain (environment, shared config/profile, EC2/ECS
+// instance role, ...).
+type awsCredentials struct {
+	profile   string
+	accessKey string
+	secretKey string
+}
+
+// loadAWSConfig resolves an aws.Config honoring, in order: an explicit
+// -aws-access-key/-aws-secret-key pair, an -aws-profile name, or the SDK's
+// default credential chain, which already covers IAM instance/role
+// credentials on EC2/ECS.
+func loadAWSConfig(ctx context.Context, c awsCredentials) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+
+	switch {
+	case c.accessKey != "" || c.secretKey != "":
+		if c.accessKey == "" || c.secretKey == "" {
+			return aws.Config{}, fmt.Errorf("loadAWSConfig: -aws-access-key and -aws-secret-key must be set together")
+		}
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(c.accessKey, c.secretKey, "")))
+	case c.profile != "":
+		opts = append(opts, config.WithSharedConfigProfile(c.profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("loadAWSConfig: %v", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	return cfg, nil
+}
+
+// newRoute53Client builds a Route53 client using the credentials resolved
+// by loadAWSConfig.
+func newRoute53Client(ctx context.Context, c awsCredentials) (*route53.Client, error) {
+	cfg, err := loadAWSConfig(ctx, c)
+	if err != nil {
+		return nil, fmt.Errorf("newRoute53Client: %v", err)
+	}
+	return route53.NewFromConfig(cfg), nil
+}
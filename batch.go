@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+const (
+	// maxChangeBatchItems is the Route53 limit on the number of "items"
+	// in a single ChangeResourceRecordSets request. An UPSERT counts as
+	// two items (a delete plus a create).
+	maxChangeBatchItems = 1000
+	// maxChangeBatchValueChars is the Route53 limit on the total number
+	// of characters across all ResourceRecord.Value fields in a single
+	// request. An UPSERT's value data counts twice for the same reason.
+	maxChangeBatchValueChars = 32000
+)
+
+// changeCost returns how many items a change consumes against
+// maxChangeBatchItems and how many characters of ResourceRecord value
+// data it consumes against maxChangeBatchValueChars.
+func changeCost(c types.Change) (items, chars int) {
+	items = 1
+	if c.ResourceRecordSet != nil {
+		for _, rr := range c.ResourceRecordSet.ResourceRecords {
+			chars += len(aws.ToString(rr.Value))
+		}
+	}
+	if c.Action == types.ChangeActionUpsert {
+		items *= 2
+		chars *= 2
+	}
+	return items, chars
+}
+
+// batchChanges greedily packs changes into groups that each respect
+// maxChangeBatchItems and maxChangeBatchValueChars, splitting before a
+// change would overflow either budget.
+func batchChanges(changes []types.Change) [][]types.Change {
+	var (
+		batches [][]types.Change
+		current []types.Change
+		items   int
+		chars   int
+	)
+
+	for _, c := range changes {
+		ci, cc := changeCost(c)
+		if len(current) > 0 && (items+ci > maxChangeBatchItems || chars+cc > maxChangeBatchValueChars) {
+			batches = append(batches, current)
+			current = nil
+			items, chars = 0, 0
+		}
+		current = append(current, c)
+		items += ci
+		chars += cc
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// upsertBatched sends changes to hostedZoneID, splitting them into as many
+// ChangeResourceRecordSets calls as batchChanges requires, and aggregates
+// every response. It stops and returns on the first error, along with the
+// responses already collected.
+func upsertBatched(ctx context.Context, svc *route53.Client, hostedZoneID string, changes []types.Change) ([]*route53.ChangeResourceRecordSetsOutput, error) {
+	var responses []*route53.ChangeResourceRecordSetsOutput
+	for _, batch := range batchChanges(changes) {
+		params := &route53.ChangeResourceRecordSetsInput{
+			ChangeBatch:  &types.ChangeBatch{Changes: batch},
+			HostedZoneId: aws.String(hostedZoneID),
+		}
+		resp, err := svc.ChangeResourceRecordSets(ctx, params)
+		if err != nil {
+			return responses, fmt.Errorf("upsertBatched: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+func change(action types.ChangeAction, value string) types.Change {
+	return types.Change{
+		Action: action,
+		ResourceRecordSet: &types.ResourceRecordSet{
+			ResourceRecords: []types.ResourceRecord{
+				{Value: aws.String(value)},
+			},
+		},
+	}
+}
+
+func TestBatchChangesExactlyThousand(t *testing.T) {
+	changes := make([]types.Change, maxChangeBatchItems)
+	for i := range changes {
+		changes[i] = change(types.ChangeActionDelete, "v")
+	}
+
+	batches := batchChanges(changes)
+	if len(batches) != 1 {
+		t.Fatalf("expected 1 batch, got %d", len(batches))
+	}
+	if len(batches[0]) != maxChangeBatchItems {
+		t.Fatalf("expected %d changes in the batch, got %d", maxChangeBatchItems, len(batches[0]))
+	}
+}
+
+func TestBatchChangesSplitsJustOverThousand(t *testing.T) {
+	changes := make([]types.Change, maxChangeBatchItems+1)
+	for i := range changes {
+		changes[i] = change(types.ChangeActionDelete, "v")
+	}
+
+	batches := batchChanges(changes)
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+	if len(batches[0]) != maxChangeBatchItems {
+		t.Fatalf("expected first batch to hold %d changes, got %d", maxChangeBatchItems, len(batches[0]))
+	}
+	if len(batches[1]) != 1 {
+		t.Fatalf("expected second batch to hold the overflow change, got %d", len(batches[1]))
+	}
+}
+
+func TestBatchChangesSplitsAtUpsertValueBoundary(t *testing.T) {
+	// Each UPSERT's value counts double: 8000 chars -> 16000 charged.
+	// Two fit exactly in the 32000 budget; a third forces a new batch.
+	value := strings.Repeat("a", 8000)
+	changes := []types.Change{
+		change(types.ChangeActionUpsert, value),
+		change(types.ChangeActionUpsert, value),
+		change(types.ChangeActionUpsert, value),
+	}
+
+	batches := batchChanges(changes)
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+	if len(batches[0]) != 2 {
+		t.Fatalf("expected first batch to hold 2 changes, got %d", len(batches[0]))
+	}
+	if len(batches[1]) != 1 {
+		t.Fatalf("expected second batch to hold the overflow change, got %d", len(batches[1]))
+	}
+}
+
+func TestBatchChangesMixedTypes(t *testing.T) {
+	changes := []types.Change{
+		change(types.ChangeActionUpsert, "1.2.3.4"),
+		change(types.ChangeActionDelete, "5.6.7.8"),
+		change(types.ChangeActionCreate, "9.10.11.12"),
+	}
+
+	batches := batchChanges(changes)
+	if len(batches) != 1 {
+		t.Fatalf("expected all 3 changes to fit in 1 batch, got %d", len(batches))
+	}
+	if len(batches[0]) != 3 {
+		t.Fatalf("expected 3 changes in the batch, got %d", len(batches[0]))
+	}
+}
+
+func TestChangeCostUpsertCountsDouble(t *testing.T) {
+	items, chars := changeCost(change(types.ChangeActionUpsert, "12345"))
+	if items != 2 {
+		t.Errorf("expected UPSERT to cost 2 items, got %d", items)
+	}
+	if chars != 10 {
+		t.Errorf("expected UPSERT to cost 10 chars, got %d", chars)
+	}
+
+	items, chars = changeCost(change(types.ChangeActionDelete, "12345"))
+	if items != 1 {
+		t.Errorf("expected DELETE to cost 1 item, got %d", items)
+	}
+	if chars != 5 {
+		t.Errorf("expected DELETE to cost 5 chars, got %d", chars)
+	}
+}
@@ -0,0 +1,50 @@
+// Package config parses the optional YAML file that lets a single
+// dyndns53 invocation keep many records, across many hosted zones, in
+// sync with the host's public IP address.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Record describes one record set to keep up to date: the hosted
+	// zone it lives in, the names sharing that configuration, its type
+	// and TTL, and an optional cron expression controlling how often it
+	// is refreshed in daemon mode. An empty Cron means "use the global
+	// -interval". Value is required for types whose data isn't the
+	// host's detected public IP (TXT, CNAME, MX, SPF) and ignored for A
+	// and AAAA.
+	Record struct {
+		Zone  string   `yaml:"zone"`
+		Names []string `yaml:"names"`
+		Type  string   `yaml:"type"`
+		TTL   int64    `yaml:"ttl"`
+		Cron  string   `yaml:"cron,omitempty"`
+		Value string   `yaml:"value,omitempty"`
+	}
+
+	// Config is the top-level shape of a `-config` YAML document.
+	Config struct {
+		Records []Record `yaml:"records"`
+	}
+)
+
+// Load reads and parses the YAML config file at path.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config.Load: %v", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("config.Load: %v", err)
+	}
+	if len(cfg.Records) == 0 {
+		return nil, fmt.Errorf("config.Load: %s defines no records", path)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/robfig/cron/v3"
+)
+
+// runDaemon keeps the process running, rechecking each record set's public
+// IP on its own schedule: a record with a -config cron expression uses it
+// verbatim, everything else falls back to interval via an "@every" spec.
+// SIGINT and SIGTERM trigger a graceful shutdown that waits for any
+// in-flight check to finish before the process exits.
+func runDaemon(ctx context.Context, svc *route53.Client, recSets []*recordSet, interval time.Duration, stateDir string) {
+	c := cron.New()
+
+	for _, rs := range recSets {
+		rs := rs
+
+		spec := rs.cron
+		if spec == "" {
+			spec = "@every " + interval.String()
+		}
+		if _, err := c.AddFunc(spec, func() {
+			if err := rs.checkAndUpdate(ctx, svc, stateDir); err != nil {
+				log.Printf("%s: %v", strings.Join(rs.names, ", "), err)
+			}
+		}); err != nil {
+			log.Fatalf("runDaemon: %s: invalid schedule %q: %v", strings.Join(rs.names, ", "), spec, err)
+		}
+	}
+
+	c.Start()
+	log.Printf("daemon started; watching %d record set(s)", len(recSets))
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	log.Print("shutting down; waiting for any in-flight check to finish")
+	<-c.Stop().Done()
+}
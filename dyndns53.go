@@ -1,38 +1,36 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
-	"os/user"
-	"path"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+
+	"github.com/jdevelop/dyndns53/config"
+	"github.com/jdevelop/dyndns53/resolver"
 )
 
 type (
 	recordSet struct {
 		names        []string
-		value        string // ip
+		value        string // ip, or the configured value for non-IP types
 		rsType       string
 		ttl          int64
 		hostedZoneID string
+		cron         string // optional per-record schedule in daemon mode
 	}
 
 	arrayFlags []string
 )
 
-const (
-	progName   = "dyndns53"
-	ipFileName = "." + progName + "-ip"
-)
+const progName = "dyndns53"
 
 var (
 	logFn string
@@ -47,20 +45,41 @@ func (i *arrayFlags) Set(value string) error {
 	return nil
 }
 
+// fqdn appends a trailing dot if s doesn't already have one, the form
+// Route53 expects for record and target names.
+func fqdn(s string) string {
+	return strings.TrimSuffix(s, ".") + "."
+}
+
 func main() {
 	log.SetPrefix(progName + ": ")
 	log.SetFlags(0)
 
 	var (
-		names  arrayFlags
-		recSet recordSet
+		names    arrayFlags
+		recSet   recordSet
+		configFn string
+		daemon   bool
+		once     bool
+		interval time.Duration
+		creds    awsCredentials
+		stateDir string
 	)
 
 	flag.Var(&names, "name", "record set names (-name domain1 -name domain2 -name domain3 ...)")
-	flag.StringVar(&recSet.rsType, "type", "A", `record set type; "A" or "AAAA"`)
+	flag.StringVar(&recSet.rsType, "type", "A", `record set type; "A", "AAAA", "TXT", "CNAME", "MX", or "SPF"`)
+	flag.StringVar(&recSet.value, "value", "", "record value for types that aren't the host's detected public IP (TXT, CNAME, MX, SPF)")
 	flag.Int64Var(&recSet.ttl, "ttl", 300, "TTL (time to live) in seconds")
-	flag.StringVar(&recSet.hostedZoneID, "zone", "", "hosted zone id")
+	flag.StringVar(&recSet.hostedZoneID, "zone", "", "hosted zone id (resolved automatically from the record name(s) when omitted)")
 	flag.StringVar(&logFn, "log", "", "file name to log to (default is stdout)")
+	flag.StringVar(&configFn, "config", "", "YAML config file describing multiple zones/records; overrides -name/-type/-ttl/-zone")
+	flag.BoolVar(&daemon, "daemon", false, "keep running and recheck the public IP on a schedule instead of exiting after one check")
+	flag.DurationVar(&interval, "interval", 5*time.Minute, "recheck interval in -daemon mode for records without their own -config cron expression")
+	flag.BoolVar(&once, "once", false, "force a single check-and-exit run; overrides -daemon")
+	flag.StringVar(&creds.profile, "aws-profile", "", "named AWS profile to use for credentials (default is the SDK's default credential chain)")
+	flag.StringVar(&creds.accessKey, "aws-access-key", "", "static AWS access key id; must be paired with -aws-secret-key")
+	flag.StringVar(&creds.secretKey, "aws-secret-key", "", "static AWS secret access key; must be paired with -aws-access-key")
+	flag.StringVar(&stateDir, "state-dir", "", "directory to cache last-applied record values in (default: $XDG_STATE_HOME/dyndns53, ~/.local/state/dyndns53, or %LOCALAPPDATA%\\dyndns53 on Windows)")
 
 	if len(os.Args) == 1 {
 		flag.Usage()
@@ -68,16 +87,51 @@ func main() {
 	}
 	flag.Parse()
 
-	recSet.names = make([]string, len(names))
-
-	for i, name := range names {
-		recSet.names[i] = strings.TrimSuffix(name, ".") + "." // append . if missing
+	var recSets []*recordSet
+	if configFn != "" {
+		cfg, err := config.Load(configFn)
+		if err != nil {
+			log.Fatal(err)
+		}
+		recSets = recordSetsFromConfig(cfg)
+	} else {
+		recSet.names = make([]string, len(names))
+		for i, name := range names {
+			recSet.names[i] = fqdn(name)
+		}
+		if recSet.rsType == "CNAME" && recSet.value != "" {
+			recSet.value = fqdn(recSet.value)
+		}
+		recSets = []*recordSet{&recSet}
 	}
 
-	if err := recSet.validate(); err != nil {
+	ctx := context.Background()
+
+	svc, err := newRoute53Client(ctx, creds)
+	if err != nil {
 		log.Fatal(err)
 	}
 
+	if stateDir == "" {
+		stateDir, err = defaultStateDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if needsZoneResolution(recSets) {
+		recSets, err = expandAutoZones(ctx, recSets, newZoneResolver(svc))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, rs := range recSets {
+		if err := rs.validate(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if logFn != "" {
 		f, err := os.OpenFile(logFn, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 		if err != nil {
@@ -89,98 +143,239 @@ func main() {
 		log.SetOutput(f)            // log to file
 	}
 
-	ip, err := currentIPAddress()
-	if err != nil {
+	if daemon && !once {
+		runDaemon(ctx, svc, recSets, interval, stateDir)
+		return
+	}
+
+	if err := runOnce(ctx, svc, recSets, stateDir); err != nil {
 		log.Fatal(err)
 	}
+}
 
-	if ip == lastIPAddress() {
-		log.Printf("current IP address is %s; nothing to do", ip)
-		os.Exit(0)
+// recordFamily reports which resolver.Family a record type is sourced
+// from, for the types whose value is the host's public IP.
+func recordFamily(rsType string) (resolver.Family, bool) {
+	switch rsType {
+	case "A":
+		return resolver.V4, true
+	case "AAAA":
+		return resolver.V6, true
+	default:
+		return 0, false
 	}
+}
 
-	recSet.value = ip
-	_, err = recSet.upsert()
-	if err != nil {
-		log.Fatal(err)
+// resolveNeededFamilies resolves, once each, every IP family that at
+// least one record set in recSets requires, so a run touching both A and
+// AAAA records does a single IPv4 + a single IPv6 lookup regardless of
+// how many record sets need them.
+func resolveNeededFamilies(ctx context.Context, recSets []*recordSet) (map[resolver.Family]string, error) {
+	needed := map[resolver.Family]bool{}
+	for _, rs := range recSets {
+		if family, ok := recordFamily(rs.rsType); ok {
+			needed[family] = true
+		}
 	}
-	log.Printf("current IP address is %s; upsert request sent", ip)
 
-	if err := updateLastIPAddress(ip); err != nil {
-		log.Fatal(err)
+	ips := make(map[resolver.Family]string, len(needed))
+	for family := range needed {
+		ip, err := resolver.Resolve(ctx, family)
+		if err != nil {
+			return nil, err
+		}
+		ips[family] = ip
 	}
+	return ips, nil
 }
 
-func currentIPAddress() (string, error) {
-	resp, err := http.Get("http://checkip.amazonaws.com/")
+// runOnce resolves every IP family needed by recSets a single time and
+// upserts whichever (name, type) pairs changed since their last recorded
+// value. This is the original one-shot behavior, still the default and
+// available explicitly via -once. Record types whose data isn't the
+// host's public IP (TXT, CNAME, MX, SPF) use their configured value as-is.
+func runOnce(ctx context.Context, svc *route53.Client, recSets []*recordSet, stateDir string) error {
+	ips, err := resolveNeededFamilies(ctx, recSets)
 	if err != nil {
-		return "", fmt.Errorf("currentIPAddress: %v", err)
+		return err
 	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("currentIPAddress: %v", err)
+
+	for _, rs := range recSets {
+		value := rs.value
+		if family, ok := recordFamily(rs.rsType); ok {
+			value = ips[family]
+		}
+		if err := rs.upsertChanged(ctx, svc, stateDir, value); err != nil {
+			return err
+		}
 	}
-	ip := strings.TrimSpace(string(body))
-	return ip, nil
+	return nil
 }
 
-func lastIPAddress() string {
-	data, err := ioutil.ReadFile(ipFileName)
-	if err != nil {
-		return ""
+// checkAndUpdate resolves rs's current value (the detected IP for A/AAAA,
+// or the configured value otherwise) and upserts whichever of its names
+// changed since their last recorded value.
+func (rs *recordSet) checkAndUpdate(ctx context.Context, svc *route53.Client, stateDir string) error {
+	value := rs.value
+	if family, ok := recordFamily(rs.rsType); ok {
+		ip, err := resolver.Resolve(ctx, family)
+		if err != nil {
+			return err
+		}
+		value = ip
 	}
-	return string(data)
+	return rs.upsertChanged(ctx, svc, stateDir, value)
 }
 
-func updateLastIPAddress(ip string) error {
-	if err := ioutil.WriteFile(ipFileName, []byte(ip), 0644); err != nil {
-		return fmt.Errorf("updateLastIPAddress: %v", err)
+// upsertChanged upserts only the names in rs whose last recorded value for
+// rs.rsType differs from value, then records value against each of them.
+func (rs *recordSet) upsertChanged(ctx context.Context, svc *route53.Client, stateDir string, value string) error {
+	var changedNames []string
+	for _, name := range rs.names {
+		key := stateKey{zone: rs.hostedZoneID, name: name, rsType: rs.rsType}
+		if lastAddress(stateDir, key) != value {
+			changedNames = append(changedNames, name)
+		}
+	}
+
+	if len(changedNames) == 0 {
+		log.Printf("%s: current %s value is %s; nothing to do", strings.Join(rs.names, ", "), rs.rsType, value)
+		return nil
+	}
+
+	changeSet := &recordSet{
+		names:        changedNames,
+		value:        value,
+		rsType:       rs.rsType,
+		ttl:          rs.ttl,
+		hostedZoneID: rs.hostedZoneID,
+	}
+	if _, err := changeSet.upsert(ctx, svc); err != nil {
+		return err
+	}
+	log.Printf("%s: current %s value is %s; upsert request sent", strings.Join(changedNames, ", "), rs.rsType, value)
+
+	for _, name := range changedNames {
+		key := stateKey{zone: rs.hostedZoneID, name: name, rsType: rs.rsType}
+		if err := updateLastAddress(stateDir, key, value); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (rs *recordSet) upsert() (*route53.ChangeResourceRecordSetsOutput, error) {
-	usr, err := user.Current()
-	if err != nil {
-		return nil, fmt.Errorf("(*recordSet).upsert: %v", err)
+// recordSetsFromConfig converts the records parsed from a YAML config file
+// into the recordSet values the rest of main operates on.
+func recordSetsFromConfig(cfg *config.Config) []*recordSet {
+	recSets := make([]*recordSet, len(cfg.Records))
+	for i, rec := range cfg.Records {
+		names := make([]string, len(rec.Names))
+		for j, name := range rec.Names {
+			names[j] = fqdn(name)
+		}
+
+		value := rec.Value
+		if rec.Type == "CNAME" && value != "" {
+			value = fqdn(value)
+		}
+
+		recSets[i] = &recordSet{
+			names:        names,
+			value:        value,
+			rsType:       rec.Type,
+			ttl:          rec.TTL,
+			hostedZoneID: rec.Zone,
+			cron:         rec.Cron,
+		}
 	}
-	credentialsPath := path.Join(usr.HomeDir, ".aws", "credentials")
-	credentials := credentials.NewSharedCredentials(credentialsPath, progName)
+	return recSets
+}
 
-	sess, err := session.NewSession()
-	if err != nil {
-		return nil, fmt.Errorf("(*recordSet).upsert: %v", err)
+// needsZoneResolution reports whether any record set omitted -zone/zone
+// and so needs its hosted zone auto-discovered.
+func needsZoneResolution(recSets []*recordSet) bool {
+	for _, rs := range recSets {
+		if rs.hostedZoneID == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// expandAutoZones resolves the hosted zone for every name in a record set
+// that omitted one, grouping names by the zone they resolve to since a
+// single ChangeResourceRecordSets call can only target one hosted zone.
+// Record sets that already specify a zone pass through unchanged.
+func expandAutoZones(ctx context.Context, recSets []*recordSet, zr *zoneResolver) ([]*recordSet, error) {
+	var out []*recordSet
+	for _, rs := range recSets {
+		if rs.hostedZoneID != "" {
+			out = append(out, rs)
+			continue
+		}
+
+		byZone := map[string]*recordSet{}
+		var order []string
+		for _, name := range rs.names {
+			zoneID, err := zr.resolve(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			grp, ok := byZone[zoneID]
+			if !ok {
+				grp = &recordSet{rsType: rs.rsType, value: rs.value, ttl: rs.ttl, hostedZoneID: zoneID, cron: rs.cron}
+				byZone[zoneID] = grp
+				order = append(order, zoneID)
+			}
+			grp.names = append(grp.names, name)
+		}
+		for _, zoneID := range order {
+			out = append(out, byZone[zoneID])
+		}
 	}
+	return out, nil
+}
 
-	svc := route53.New(sess, &aws.Config{Credentials: credentials})
-	changes := make([]*route53.Change, len(rs.names))
+// upsert sends an UPSERT change for every name in rs, batching the
+// requests so none exceeds Route53's ChangeBatch limits (see batch.go).
+func (rs *recordSet) upsert(ctx context.Context, svc *route53.Client) ([]*route53.ChangeResourceRecordSetsOutput, error) {
+	value := rs.value
+	if rs.rsType == "TXT" || rs.rsType == "SPF" {
+		value = quoteTXT(value)
+	}
+
+	changes := make([]types.Change, len(rs.names))
 	for i, name := range rs.names {
-		changes[i] = &route53.Change{
-			Action: aws.String("UPSERT"),
-			ResourceRecordSet: &route53.ResourceRecordSet{
+		changes[i] = types.Change{
+			Action: types.ChangeActionUpsert,
+			ResourceRecordSet: &types.ResourceRecordSet{
 				Name: aws.String(name),
-				Type: aws.String(rs.rsType),
+				Type: types.RRType(rs.rsType),
 				TTL:  aws.Int64(rs.ttl),
-				ResourceRecords: []*route53.ResourceRecord{
-					{
-						Value: aws.String(rs.value),
-					},
+				ResourceRecords: []types.ResourceRecord{
+					{Value: aws.String(value)},
 				},
 			},
 		}
 	}
-	params := &route53.ChangeResourceRecordSetsInput{
-		ChangeBatch: &route53.ChangeBatch{
-			Changes: changes,
-		},
-		HostedZoneId: aws.String(rs.hostedZoneID),
-	}
-	resp, err := svc.ChangeResourceRecordSets(params)
+
+	resps, err := upsertBatched(ctx, svc, rs.hostedZoneID, changes)
 	if err != nil {
-		return nil, fmt.Errorf("(*recordSet).upsert: %v", err)
+		return resps, fmt.Errorf("(*recordSet).upsert: %v", err)
 	}
-	return resp, nil
+	return resps, nil
+}
+
+// supportedTypes lists the record types upsert knows how to write: A and
+// AAAA carry the host's detected public IP, the rest carry a value
+// configured explicitly.
+var supportedTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"TXT":   true,
+	"CNAME": true,
+	"MX":    true,
+	"SPF":   true,
 }
 
 func (rs *recordSet) validate() error {
@@ -192,7 +387,7 @@ func (rs *recordSet) validate() error {
 	if rs.rsType == "" {
 		return fmt.Errorf("missing record set type")
 	}
-	if rs.rsType != "A" && rs.rsType != "AAAA" {
+	if !supportedTypes[rs.rsType] {
 		return fmt.Errorf("invalid record set type: %s", rs.rsType)
 	}
 	if rs.ttl < 1 {
@@ -201,5 +396,8 @@ func (rs *recordSet) validate() error {
 	if rs.hostedZoneID == "" {
 		return fmt.Errorf("missing hosted zone id")
 	}
+	if _, isIP := recordFamily(rs.rsType); !isIP && rs.value == "" {
+		return fmt.Errorf("missing value for record type %s", rs.rsType)
+	}
 	return nil
 }
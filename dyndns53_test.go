@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jdevelop/dyndns53/config"
+)
+
+func TestRecordSetsFromConfigNormalizesCNAMETarget(t *testing.T) {
+	cfg := &config.Config{
+		Records: []config.Record{
+			{
+				Zone:  "Z1234",
+				Names: []string{"www.example.com"},
+				Type:  "CNAME",
+				TTL:   300,
+				Value: "example.com", // no trailing dot
+			},
+		},
+	}
+
+	recSets := recordSetsFromConfig(cfg)
+	if len(recSets) != 1 {
+		t.Fatalf("expected 1 record set, got %d", len(recSets))
+	}
+
+	got := recSets[0].value
+	want := "example.com."
+	if got != want {
+		t.Fatalf("CNAME value = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "strings"
+
+// txtChunkSize is the maximum length of a single quoted string within a
+// TXT/SPF record's value, per Route53's limit.
+const txtChunkSize = 255
+
+// quoteTXT formats value the way Route53 expects TXT/SPF record data:
+// one or more double-quoted strings, split every txtChunkSize bytes,
+// joined with a space.
+func quoteTXT(value string) string {
+	if value == "" {
+		return `""`
+	}
+
+	var chunks []string
+	for len(value) > 0 {
+		n := txtChunkSize
+		if n > len(value) {
+			n = len(value)
+		}
+		chunks = append(chunks, `"`+value[:n]+`"`)
+		value = value[n:]
+	}
+	return strings.Join(chunks, " ")
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteTXTSplitsLongValueIntoChunks(t *testing.T) {
+	value := strings.Repeat("a", 300)
+
+	got := quoteTXT(value)
+
+	want := `"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("a", 45) + `"`
+	if got != want {
+		t.Fatalf("quoteTXT(300 bytes) = %q, want %q", got, want)
+	}
+}
+
+func TestQuoteTXTShortValueIsSingleChunk(t *testing.T) {
+	got := quoteTXT("hello")
+	if got != `"hello"` {
+		t.Fatalf("quoteTXT(%q) = %q, want %q", "hello", got, `"hello"`)
+	}
+}
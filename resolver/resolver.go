@@ -0,0 +1,91 @@
+// Package resolver detects the host's current public IP address,
+// independently for IPv4 and IPv6, falling back across several providers
+// so a single outage doesn't halt updates.
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Family selects which IP version to resolve.
+type Family int
+
+const (
+	V4 Family = iota
+	V6
+)
+
+func (f Family) String() string {
+	if f == V6 {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
+// providers lists, per family, the endpoints tried in order until one
+// succeeds.
+var providers = map[Family][]string{
+	V4: {"https://api.ipify.org", "https://ipv4.icanhazip.com"},
+	V6: {"https://api6.ipify.org", "https://ipv6.icanhazip.com"},
+}
+
+const dialTimeout = 10 * time.Second
+
+// Resolve returns the host's current public IP address for family,
+// forcing the underlying connection onto that network.
+func Resolve(ctx context.Context, family Family) (string, error) {
+	var lastErr error
+	for _, url := range providers[family] {
+		ip, err := fetch(ctx, url, family)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return ip, nil
+	}
+	return "", fmt.Errorf("resolver.Resolve: %s: all providers failed: %v", family, lastErr)
+}
+
+func fetch(ctx context.Context, url string, family Family) (string, error) {
+	network := "tcp4"
+	if family == V6 {
+		network = "tcp6"
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	client := &http.Client{
+		Timeout: dialTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("resolver.fetch: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("resolver.fetch: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolver.fetch: %s: unexpected status %s", url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("resolver.fetch: %v", err)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
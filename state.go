@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// stateKey identifies one (hosted zone, name, type) triple whose
+// last-applied value is cached independently, so records that share a
+// name but differ in zone or type don't clobber each other's cache entry.
+type stateKey struct {
+	zone   string
+	name   string
+	rsType string
+}
+
+// defaultStateDir returns the per-user directory dyndns53 caches
+// last-applied values in when -state-dir isn't given: $XDG_STATE_HOME/dyndns53
+// on Unix, falling back to ~/.local/state/dyndns53, or
+// %LOCALAPPDATA%\dyndns53 on Windows.
+func defaultStateDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, progName), nil
+		}
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, progName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("defaultStateDir: %v", err)
+	}
+	return filepath.Join(home, ".local", "state", progName), nil
+}
+
+// fileName derives a per-key cache file name from the zone, name and
+// record type, so records that differ in any one of those get their own
+// cache entry under dir.
+func (k stateKey) fileName(dir string) string {
+	safe := strings.NewReplacer("/", "_", "*", "_").Replace(
+		fmt.Sprintf("%s-%s-%s", k.zone, strings.Trim(k.name, "."), k.rsType))
+	return filepath.Join(dir, safe)
+}
+
+func lastAddress(dir string, k stateKey) string {
+	data, err := ioutil.ReadFile(k.fileName(dir))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func updateLastAddress(dir string, k stateKey, value string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("updateLastAddress: %v", err)
+	}
+	if err := ioutil.WriteFile(k.fileName(dir), []byte(value), 0644); err != nil {
+		return fmt.Errorf("updateLastAddress: %v", err)
+	}
+	return nil
+}
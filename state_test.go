@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestStateKeyFileNameDiffersByZoneNameAndType(t *testing.T) {
+	base := stateKey{zone: "Z1", name: "home.example.com.", rsType: "A"}
+	variants := []stateKey{
+		{zone: "Z2", name: base.name, rsType: base.rsType},
+		{zone: base.zone, name: "other.example.com.", rsType: base.rsType},
+		{zone: base.zone, name: base.name, rsType: "AAAA"},
+	}
+
+	baseName := base.fileName("/state")
+	for _, v := range variants {
+		if v.fileName("/state") == baseName {
+			t.Fatalf("expected %+v to produce a different file name than %+v, both got %q", v, base, baseName)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+)
+
+// zoneResolver maps record names to hosted zone IDs by the longest
+// matching suffix against ListHostedZones, caching the zone list for the
+// lifetime of the process so repeated lookups don't re-hit the API.
+type zoneResolver struct {
+	svc   *route53.Client
+	zones []types.HostedZone
+}
+
+func newZoneResolver(svc *route53.Client) *zoneResolver {
+	return &zoneResolver{svc: svc}
+}
+
+func (z *zoneResolver) load(ctx context.Context) error {
+	if z.zones != nil {
+		return nil
+	}
+
+	var zones []types.HostedZone
+	p := route53.NewListHostedZonesPaginator(z.svc, &route53.ListHostedZonesInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("(*zoneResolver).load: %v", err)
+		}
+		zones = append(zones, page.HostedZones...)
+	}
+
+	z.zones = zones
+	return nil
+}
+
+// resolve returns the ID of the hosted zone whose name is the longest
+// suffix match for name (a fully-qualified, trailing-dot record name). It
+// errors if no zone matches, or if more than one zone matches equally well.
+func (z *zoneResolver) resolve(ctx context.Context, name string) (string, error) {
+	if err := z.load(ctx); err != nil {
+		return "", err
+	}
+
+	var matches []types.HostedZone
+	maxLen := -1
+	for _, zone := range z.zones {
+		zoneName := aws.ToString(zone.Name)
+		if !strings.HasSuffix(name, zoneName) {
+			continue
+		}
+		switch {
+		case len(zoneName) > maxLen:
+			maxLen = len(zoneName)
+			matches = []types.HostedZone{zone}
+		case len(zoneName) == maxLen:
+			matches = append(matches, zone)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("(*zoneResolver).resolve: no hosted zone matches %s", name)
+	case 1:
+		return aws.ToString(matches[0].Id), nil
+	default:
+		return "", fmt.Errorf("(*zoneResolver).resolve: %s matches %d hosted zones", name, len(matches))
+	}
+}